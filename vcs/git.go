@@ -3,6 +3,8 @@ package vcs
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,21 +13,39 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
 const defaultRef = "master"
 
+const (
+	attrGenerated     = "linguist-generated"
+	attrVendored      = "linguist-vendored"
+	attrDocumentation = "linguist-documentation"
+)
+
 var headBranchRegexp = regexp.MustCompile(`HEAD branch: (?P<branch>.+)`)
-var autoGeneratedFileRegexp = regexp.MustCompile(`(?P<path>.+) linguist-generated=true`)
 
 func init() {
 	Register(newGit, "git")
 }
 
 type GitDriver struct {
-	DetectRef     bool   `json:"detect-ref"`
-	Ref           string `json:"ref"`
+	DetectRef      bool              `json:"detect-ref"`
+	Ref            string            `json:"ref"`
+	Username       string            `json:"username"`
+	Password       string            `json:"password"`
+	PasswordEnv    string            `json:"password-env"`
+	SSHKey         string            `json:"ssh-key"`
+	SSHKeyEnv      string            `json:"ssh-key-env"`
+	HTTPHeaders    map[string]string `json:"http-headers"`
+	ShallowSince   string            `json:"shallow-since"`
+	Filter         string            `json:"filter"`
+	SingleBranch   bool              `json:"single-branch"`
+	SparseCheckout []string          `json:"sparse-checkout"`
+	Submodules     string            `json:"submodules"`
+
 	refDetetector refDetetector
 }
 
@@ -36,7 +56,42 @@ type refDetetector interface {
 type headBranchDetector struct {
 }
 
+// authContext carries the environment variables and cleanup callback
+// needed to authenticate a single git invocation. Call release() once
+// the invocation has finished so any temp files (askpass helper, ssh
+// key) are removed.
+type authContext struct {
+	env     []string
+	release func()
+}
+
+// backendConfig is used to sniff the `backend` field out of the repo
+// config before deciding which Driver implementation to construct.
+type backendConfig struct {
+	Backend string `json:"backend"`
+}
+
 func newGit(b []byte) (Driver, error) {
+	var bc backendConfig
+	if b != nil {
+		if err := json.Unmarshal(b, &bc); err != nil {
+			return nil, err
+		}
+	}
+
+	if bc.Backend == "go-git" || (bc.Backend == "" && !hasGitBinary()) {
+		unsupported, err := goGitUnsupportedFields(b)
+		if err != nil {
+			return nil, err
+		}
+		if len(unsupported) > 0 {
+			log.Printf(
+				"go-git backend does not support %s; these settings will be ignored",
+				strings.Join(unsupported, ", "))
+		}
+		return newGoGit(b)
+	}
+
 	var d GitDriver
 
 	if b != nil {
@@ -50,6 +105,175 @@ func newGit(b []byte) (Driver, error) {
 	return &d, nil
 }
 
+// hasGitBinary reports whether a `git` executable can be found on PATH.
+// When it can't, newGit falls back to the pure-Go go-git backend so
+// Hound keeps working without a system git dependency.
+func hasGitBinary() bool {
+	_, err := exec.LookPath("git")
+	return err == nil
+}
+
+// goGitUnsupportedFields reports which GitDriver-only config fields in b
+// are set despite not being supported by GoGitDriver (HTTPHeaders,
+// ShallowSince, Filter, SparseCheckout, Submodules), so newGit can warn
+// instead of silently ignoring them when falling back to go-git.
+func goGitUnsupportedFields(b []byte) ([]string, error) {
+	if b == nil {
+		return nil, nil
+	}
+
+	var d GitDriver
+	if err := json.Unmarshal(b, &d); err != nil {
+		return nil, err
+	}
+
+	var unsupported []string
+	if len(d.HTTPHeaders) > 0 {
+		unsupported = append(unsupported, "http-headers")
+	}
+	if d.ShallowSince != "" {
+		unsupported = append(unsupported, "shallow-since")
+	}
+	if d.Filter != "" {
+		unsupported = append(unsupported, "filter")
+	}
+	if len(d.SparseCheckout) > 0 {
+		unsupported = append(unsupported, "sparse-checkout")
+	}
+	if d.submodulesEnabled() {
+		unsupported = append(unsupported, "submodules")
+	}
+
+	return unsupported, nil
+}
+
+// auth builds the environment and config args needed to authenticate
+// against the git remote at url, based on the configured credentials.
+// The returned authContext.release() must be called to clean up any
+// temp files it created, even on error paths in the caller. url is used
+// to scope any configured HTTPHeaders to that remote alone, via
+// `http.<url>.extraHeader`, so they aren't sent to unrelated hosts the
+// git process may talk to (e.g. redirect targets); pass "" to skip
+// applying headers when the remote URL isn't known. Header values are
+// passed via the GIT_CONFIG_KEY_n/GIT_CONFIG_VALUE_n environment
+// variables rather than `-c` on argv, the same as Password and SSHKey
+// above, so they never show up in a process listing.
+func (g *GitDriver) auth(url string) (*authContext, error) {
+	ac := &authContext{
+		env:     os.Environ(),
+		release: func() {},
+	}
+
+	var cleanups []func()
+	ac.release = func() {
+		for _, c := range cleanups {
+			c()
+		}
+	}
+
+	password := g.Password
+	if g.PasswordEnv != "" {
+		password = os.Getenv(g.PasswordEnv)
+	}
+
+	if g.Username != "" || password != "" {
+		askpass, cleanup, err := writeAskpassScript(g.Username, password)
+		if err != nil {
+			ac.release()
+			return nil, fmt.Errorf("failed to write GIT_ASKPASS helper: %v", err)
+		}
+		cleanups = append(cleanups, cleanup)
+		ac.env = append(ac.env,
+			"GIT_ASKPASS="+askpass,
+			"GIT_TERMINAL_PROMPT=0")
+	}
+
+	sshKey := g.SSHKey
+	if g.SSHKeyEnv != "" {
+		sshKey = os.Getenv(g.SSHKeyEnv)
+	}
+
+	if sshKey != "" {
+		keyPath, cleanup, err := writeSSHKey(sshKey)
+		if err != nil {
+			ac.release()
+			return nil, fmt.Errorf("failed to write ssh key: %v", err)
+		}
+		cleanups = append(cleanups, cleanup)
+		ac.env = append(ac.env,
+			fmt.Sprintf("GIT_SSH_COMMAND=ssh -i %s -o IdentitiesOnly=yes", keyPath))
+	}
+
+	if url != "" && len(g.HTTPHeaders) > 0 {
+		ac.env = append(ac.env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", len(g.HTTPHeaders)))
+
+		i := 0
+		for name, value := range g.HTTPHeaders {
+			ac.env = append(ac.env,
+				fmt.Sprintf("GIT_CONFIG_KEY_%d=http.%s.extraHeader", i, url),
+				fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s: %s", i, name, value))
+			i++
+		}
+	}
+
+	return ac, nil
+}
+
+// writeAskpassScript writes a small helper script that git invokes as
+// GIT_ASKPASS in place of prompting on a tty. The username/password are
+// embedded in the script rather than passed as arguments so they never
+// show up in a process listing.
+func writeAskpassScript(username, password string) (string, func(), error) {
+	f, err := os.CreateTemp("", "hound-askpass-*.sh")
+	if err != nil {
+		return "", nil, err
+	}
+
+	script := fmt.Sprintf("#!/bin/sh\ncase \"$1\" in\n*sername*) echo %s ;;\n*) echo %s ;;\nesac\n",
+		shellQuote(username), shellQuote(password))
+
+	if _, err := f.WriteString(script); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", nil, err
+	}
+	f.Close()
+
+	if err := os.Chmod(f.Name(), 0700); err != nil {
+		os.Remove(f.Name())
+		return "", nil, err
+	}
+
+	return f.Name(), func() { os.Remove(f.Name()) }, nil
+}
+
+// writeSSHKey writes the given private key material to a temp file with
+// permissions narrow enough for ssh to accept it, returning its path.
+func writeSSHKey(key string) (string, func(), error) {
+	f, err := os.CreateTemp("", "hound-ssh-key-*")
+	if err != nil {
+		return "", nil, err
+	}
+
+	if _, err := f.WriteString(key); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", nil, err
+	}
+	f.Close()
+
+	if err := os.Chmod(f.Name(), 0600); err != nil {
+		os.Remove(f.Name())
+		return "", nil, err
+	}
+
+	return f.Name(), func() { os.Remove(f.Name()) }, nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 func (g *GitDriver) HeadRev(dir string) (string, error) {
 	cmd := exec.Command(
 		"git",
@@ -72,12 +296,72 @@ func (g *GitDriver) HeadRev(dir string) (string, error) {
 		return "", err
 	}
 
-	return strings.TrimSpace(buf.String()), cmd.Wait()
+	head := strings.TrimSpace(buf.String())
+	if err := cmd.Wait(); err != nil {
+		return "", err
+	}
+
+	if g.submodulesEnabled() {
+		if summary, err := submoduleSummary(dir); err == nil && summary != "" {
+			head = fmt.Sprintf("%s+submodules:%s", head, summary)
+		}
+	}
+
+	return head, nil
+}
+
+func (g *GitDriver) submodulesEnabled() bool {
+	return g.Submodules != "" && g.Submodules != "none"
+}
+
+// submoduleInitArgs builds the argument list used to populate
+// submodules right after a fresh clone.
+func (g *GitDriver) submoduleInitArgs() []string {
+	args := []string{"submodule", "update", "--init", "--depth", "1"}
+	if g.Submodules == "recursive" {
+		args = append(args, "--recursive")
+	}
+	return args
+}
+
+// submoduleUpdateArgs builds the argument list used to advance
+// submodules to their upstream tracking branch on a subsequent pull.
+func (g *GitDriver) submoduleUpdateArgs() []string {
+	args := []string{"submodule", "update", "--remote"}
+	if g.Submodules == "recursive" {
+		args = append(args, "--recursive")
+	}
+	return args
+}
+
+// submoduleSummary returns a short hash of `git submodule status
+// --recursive`'s output, so that HeadRev changes whenever a submodule
+// pointer moves even though the superproject's own HEAD did not.
+func submoduleSummary(dir string) (string, error) {
+	out, err := run("git submodule status", dir, "git", "submodule", "status", "--recursive")
+	if err != nil {
+		return "", err
+	}
+
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return "", nil
+	}
+
+	sum := sha1.Sum([]byte(out))
+	return hex.EncodeToString(sum[:])[:12], nil
 }
 
 func run(desc, dir, cmd string, args ...string) (string, error) {
+	return runWithEnv(desc, dir, nil, cmd, args...)
+}
+
+func runWithEnv(desc, dir string, env []string, cmd string, args ...string) (string, error) {
 	c := exec.Command(cmd, args...)
 	c.Dir = dir
+	if env != nil {
+		c.Env = env
+	}
 	out, err := c.CombinedOutput()
 	if err != nil {
 		log.Printf(
@@ -90,18 +374,54 @@ func run(desc, dir, cmd string, args ...string) (string, error) {
 	return string(out), nil
 }
 
+// runChecked behaves like runWithEnv but returns the command's actual
+// failure instead of logging it and continuing. Use it for steps whose
+// caller needs to know definitively whether the working tree ended up
+// complete (e.g. sparse-checkout, submodule setup), as opposed to
+// best-effort steps like ref detection that are fine to log and move
+// past.
+func runChecked(desc, dir string, env []string, cmd string, args ...string) (string, error) {
+	c := exec.Command(cmd, args...)
+	c.Dir = dir
+	if env != nil {
+		c.Env = env
+	}
+	out, err := c.CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("failed to %s: %v\n%s", desc, err, out)
+	}
+
+	return string(out), nil
+}
+
 func (g *GitDriver) Pull(dir string) (string, error) {
+	if err := g.fetchAndReset(dir); err != nil {
+		return "", err
+	}
+
+	if g.submodulesEnabled() {
+		if _, err := runChecked("git submodule update", dir, nil, "git", g.submoduleUpdateArgs()...); err != nil {
+			return "", err
+		}
+	}
+
+	return g.HeadRev(dir)
+}
+
+// fetchAndReset fetches the target ref from origin and hard-resets the
+// working tree to it. It is the shared core of Pull and Clone; the two
+// differ only in what they do with submodules afterwards.
+func (g *GitDriver) fetchAndReset(dir string) error {
 	targetRef := g.targetRef(dir)
 
-	if _, err := run("git fetch", dir,
-		"git",
-		"fetch",
-		"--prune",
-		"--no-tags",
-		"--depth", "1",
-		"origin",
-		fmt.Sprintf("+%s:remotes/origin/%s", targetRef, targetRef)); err != nil {
-		return "", err
+	ac, err := g.auth(remoteOriginURL(dir))
+	if err != nil {
+		return err
+	}
+	defer ac.release()
+
+	if _, err := runWithEnv("git fetch", dir, ac.env, "git", g.fetchArgs(targetRef)...); err != nil {
+		return err
 	}
 
 	if _, err := run("git reset", dir,
@@ -109,10 +429,59 @@ func (g *GitDriver) Pull(dir string) (string, error) {
 		"reset",
 		"--hard",
 		fmt.Sprintf("origin/%s", targetRef)); err != nil {
-		return "", err
+		return err
 	}
 
-	return g.HeadRev(dir)
+	return nil
+}
+
+// fetchArgs builds the argument list for the `git fetch` invocation in
+// Pull, preferring a `--shallow-since` deepening window over a flat
+// `--depth 1` when one is configured, and narrowing to a single branch
+// when requested. Like cloneArgs, it skips the depth truncation
+// entirely when Filter is set: a partial clone wants full history with
+// blob content filtered out, not a shallow one, and re-truncating to
+// depth 1 on every fetch would silently defeat that.
+func (g *GitDriver) fetchArgs(targetRef string) []string {
+	args := []string{"fetch", "--prune", "--no-tags"}
+
+	switch {
+	case g.ShallowSince != "":
+		args = append(args, "--shallow-since", g.ShallowSince)
+	case g.Filter == "":
+		args = append(args, "--depth", "1")
+	}
+
+	if g.SingleBranch {
+		args = append(args, "--single-branch")
+	}
+
+	return append(args, "origin", fmt.Sprintf("+%s:remotes/origin/%s", targetRef, targetRef))
+}
+
+// cloneArgs builds the argument list for the `git clone` invocation. When
+// Filter is set, it performs a partial clone (`--filter=...
+// --no-checkout`); the working tree is then populated by the hard reset
+// in fetchAndReset, limited to the SparseCheckout patterns when any are
+// set.
+func (g *GitDriver) cloneArgs(url, rep string) []string {
+	args := []string{"clone"}
+
+	if g.Filter != "" {
+		args = append(args, "--filter", g.Filter, "--no-checkout")
+	} else {
+		args = append(args, "--depth", "1")
+	}
+
+	if g.ShallowSince != "" {
+		args = append(args, "--shallow-since", g.ShallowSince)
+	}
+
+	if g.SingleBranch {
+		args = append(args, "--single-branch")
+	}
+
+	return append(args, url, rep)
 }
 
 func (g *GitDriver) targetRef(dir string) string {
@@ -130,31 +499,189 @@ func (g *GitDriver) targetRef(dir string) string {
 	return targetRef
 }
 
+// remoteOriginURL returns the URL configured for the "origin" remote in
+// dir, or "" if it can't be determined. It's used to scope HTTPHeaders
+// to that remote when fetching, since fetchAndReset only knows the repo
+// directory rather than the original clone URL.
+func remoteOriginURL(dir string) string {
+	out, err := run("git config", dir, "git", "config", "--get", "remote.origin.url")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
 func (g *GitDriver) Clone(dir, url string) (string, error) {
 	par, rep := filepath.Split(dir)
-	cmd := exec.Command(
-		"git",
-		"clone",
-		"--depth", "1",
-		url,
-		rep)
+
+	ac, err := g.auth(url)
+	if err != nil {
+		return "", err
+	}
+	defer ac.release()
+
+	cmd := exec.Command("git", g.cloneArgs(url, rep)...)
 	cmd.Dir = par
+	cmd.Env = ac.env
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		log.Printf("Failed to clone %s, see output below\n%sContinuing...", url, out)
 		return "", err
 	}
 
-	return g.Pull(dir)
+	if g.Filter != "" && len(g.SparseCheckout) > 0 {
+		sparseArgs := append([]string{"sparse-checkout", "set"}, g.SparseCheckout...)
+		if _, err := runChecked("git sparse-checkout", dir, ac.env, "git", sparseArgs...); err != nil {
+			return "", err
+		}
+	}
+
+	if err := g.fetchAndReset(dir); err != nil {
+		return "", err
+	}
+
+	if g.submodulesEnabled() {
+		if _, err := runChecked("git submodule init", dir, nil, "git", g.submoduleInitArgs()...); err != nil {
+			return "", err
+		}
+	}
+
+	return g.HeadRev(dir)
+}
+
+// RefInfo describes a single ref discovered on a remote, as returned by
+// Discoverer.DiscoverRefs.
+type RefInfo struct {
+	Name string // ref name with the refs/heads/ or refs/tags/ prefix stripped, e.g. "release/1.2"
+	Type string // "heads" or "tags"
+	SHA  string
+}
+
+// Discoverer is implemented by drivers that can enumerate the refs
+// available on a remote without first cloning it, so a single repos:
+// entry with a `refs` glob can be expanded into one indexed working copy
+// per matching ref.
+//
+// Nothing in this package calls DiscoverRefs yet: expanding a repos:
+// entry into N working copies is the config loader's job, and that
+// loader doesn't exist in this tree. Treat this interface as the
+// discovery half of that feature, not the feature itself, until
+// something upstream of GitDriver actually calls it per matching ref.
+type Discoverer interface {
+	DiscoverRefs(url string, patterns []string) ([]RefInfo, error)
+}
+
+// DiscoverRefs lists the branches and tags on url, filtered down to
+// those whose name matches at least one of patterns (shell glob syntax,
+// e.g. "release/*"). An empty patterns list matches every ref.
+func (g *GitDriver) DiscoverRefs(url string, patterns []string) ([]RefInfo, error) {
+	ac, err := g.auth(url)
+	if err != nil {
+		return nil, err
+	}
+	defer ac.release()
+
+	args := []string{"ls-remote", "--heads", "--tags", "-q", url}
+
+	out, err := runWithEnv("git ls-remote", "", ac.env, "git", args...)
+	if err != nil {
+		return nil, fmt.Errorf("git ls-remote %s: %v", url, err)
+	}
+
+	var refs []RefInfo
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+
+		sha, ref := fields[0], fields[1]
+
+		// Annotated tags are listed twice: once for the tag object and
+		// once peeled to the commit it points at, with a "^{}" suffix.
+		// Skip the peeled entry; we want the tag name pointing at the
+		// commit, so prefer the peeled SHA over the tag object's.
+		if strings.HasSuffix(ref, "^{}") {
+			ref = strings.TrimSuffix(ref, "^{}")
+			for i := range refs {
+				if refs[i].Type == "tags" && "refs/tags/"+refs[i].Name == ref {
+					refs[i].SHA = sha
+				}
+			}
+			continue
+		}
+
+		var refType, name string
+		switch {
+		case strings.HasPrefix(ref, "refs/heads/"):
+			refType, name = "heads", strings.TrimPrefix(ref, "refs/heads/")
+		case strings.HasPrefix(ref, "refs/tags/"):
+			refType, name = "tags", strings.TrimPrefix(ref, "refs/tags/")
+		default:
+			continue
+		}
+
+		if len(patterns) > 0 && !matchesAnyRefPattern(name, patterns) {
+			continue
+		}
+
+		refs = append(refs, RefInfo{Name: name, Type: refType, SHA: sha})
+	}
+
+	return refs, nil
+}
+
+func matchesAnyRefPattern(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, err := filepath.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
 }
 
 func (g *GitDriver) SpecialFiles() []string {
-	return []string{
-		".git",
+	files := []string{".git"}
+	if g.submodulesEnabled() {
+		files = append(files, ".gitmodules")
 	}
+	return files
 }
 
 func (g *GitDriver) AutoGeneratedFilePatterns(dir string) []string {
+	return attributeFilePatterns(dir, attrGenerated)
+}
+
+// VendoredFilePatterns returns the glob patterns (translated to regexp
+// syntax, as AutoGeneratedFilePatterns does) marked `linguist-vendored=true`
+// in .gitattributes, e.g. vendored dependency trees like `vendor/` or
+// `node_modules/`.
+func (g *GitDriver) VendoredFilePatterns(dir string) []string {
+	return attributeFilePatterns(dir, attrVendored)
+}
+
+// DocumentationFilePatterns returns the glob patterns marked
+// `linguist-documentation=true` in .gitattributes.
+func (g *GitDriver) DocumentationFilePatterns(dir string) []string {
+	return attributeFilePatterns(dir, attrDocumentation)
+}
+
+// ExcludedFilePatterns is a convenience that combines the generated,
+// vendored, and documentation patterns into the single list callers
+// typically want to exclude from search results.
+func (g *GitDriver) ExcludedFilePatterns(dir string) []string {
+	var patterns []string
+	patterns = append(patterns, g.AutoGeneratedFilePatterns(dir)...)
+	patterns = append(patterns, g.VendoredFilePatterns(dir)...)
+	patterns = append(patterns, g.DocumentationFilePatterns(dir)...)
+	return patterns
+}
+
+// attributeFilePatterns scans .gitattributes in dir for paths that have
+// the given attribute explicitly set to true, returning each matching
+// path translated from gitattributes glob syntax to a regexp pattern.
+func attributeFilePatterns(dir, attr string) []string {
 	var filePatterns []string
 	path := filepath.Join(dir, ".gitattributes")
 
@@ -166,10 +693,7 @@ func (g *GitDriver) AutoGeneratedFilePatterns(dir string) []string {
 
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		matches := autoGeneratedFileRegexp.FindStringSubmatch(scanner.Text())
-		if len(matches) == 2 {
-			pattern := strings.ReplaceAll(matches[1], "**", "*")
-			pattern = strings.ReplaceAll(pattern, "*", ".*")
+		if pattern, ok := attrPatternFromLine(scanner.Text(), attr); ok {
 			filePatterns = append(filePatterns, pattern)
 		}
 	}
@@ -177,6 +701,103 @@ func (g *GitDriver) AutoGeneratedFilePatterns(dir string) []string {
 	return filePatterns
 }
 
+// attrPatternFromLine parses a single .gitattributes line and, if it
+// sets attr to true for its path, returns that path as a regexp
+// pattern. It understands the unset forms (`-attr`, `attr=false`) as
+// well as quoted paths containing spaces.
+func attrPatternFromLine(line, attr string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", false
+	}
+
+	fields := splitAttrFields(line)
+	if len(fields) < 2 {
+		return "", false
+	}
+
+	path := fields[0]
+	if strings.HasPrefix(path, `"`) {
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			path = unquoted
+		}
+	}
+
+	for _, field := range fields[1:] {
+		name, isSet := parseAttr(field)
+		if name != attr {
+			continue
+		}
+		if !isSet {
+			return "", false
+		}
+		return globToPattern(path), true
+	}
+
+	return "", false
+}
+
+// splitAttrFields tokenizes a .gitattributes line on whitespace like
+// strings.Fields, except a double-quoted path (which may itself contain
+// spaces and backslash escapes) is kept as a single field so its quotes
+// survive for strconv.Unquote to strip.
+func splitAttrFields(line string) []string {
+	var fields []string
+	var cur strings.Builder
+	inQuotes := false
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+
+		if inQuotes && c == '\\' && i+1 < len(line) {
+			cur.WriteByte(c)
+			i++
+			cur.WriteByte(line[i])
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+			cur.WriteByte(c)
+		case (c == ' ' || c == '\t') && !inQuotes:
+			if cur.Len() > 0 {
+				fields = append(fields, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteByte(c)
+		}
+	}
+
+	if cur.Len() > 0 {
+		fields = append(fields, cur.String())
+	}
+
+	return fields
+}
+
+// parseAttr splits a single gitattributes attribute token (`name`,
+// `-name`, `name=true`, `name=false`) into its name and whether it is
+// being set (as opposed to unset or explicitly disabled).
+func parseAttr(field string) (name string, isSet bool) {
+	if strings.HasPrefix(field, "-") {
+		return field[1:], false
+	}
+
+	if idx := strings.Index(field, "="); idx >= 0 {
+		return field[:idx], field[idx+1:] == "true"
+	}
+
+	return field, true
+}
+
+func globToPattern(glob string) string {
+	pattern := strings.ReplaceAll(glob, "**", "*")
+	pattern = strings.ReplaceAll(pattern, "*", ".*")
+	return pattern
+}
+
 func (d *headBranchDetector) detectRef(dir string) string {
 	output, err := run("git show remote info", dir,
 		"git",
@@ -0,0 +1,156 @@
+package vcs
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+// GoGitDriver is a Driver implementation backed entirely by the pure-Go
+// go-git library, with no dependency on a `git` binary being present on
+// PATH. It implements the same surface as GitDriver (HeadRev, Pull,
+// Clone, SpecialFiles, AutoGeneratedFilePatterns and friends) but
+// returns real Go errors instead of logging a failure and continuing,
+// which makes it suitable for embedding Hound as a library.
+//
+// It is selected over the shell-backed GitDriver when the repo config
+// sets `"backend": "go-git"`, or automatically when no `git` binary can
+// be found on PATH.
+type GoGitDriver struct {
+	Ref      string `json:"ref"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	SSHKey   string `json:"ssh-key"`
+}
+
+func newGoGit(b []byte) (Driver, error) {
+	var d GoGitDriver
+
+	if b != nil {
+		if err := json.Unmarshal(b, &d); err != nil {
+			return nil, err
+		}
+	}
+
+	return &d, nil
+}
+
+// referenceName returns the branch ref to check out, or "" to leave it
+// unset so go-git follows the remote's own HEAD (its default branch)
+// instead of assuming one.
+func (g *GoGitDriver) referenceName() plumbing.ReferenceName {
+	if g.Ref == "" {
+		return ""
+	}
+	return plumbing.NewBranchReferenceName(g.Ref)
+}
+
+func (g *GoGitDriver) auth() (transport.AuthMethod, error) {
+	if g.SSHKey != "" {
+		auth, err := gitssh.NewPublicKeys("git", []byte(g.SSHKey), "")
+		if err != nil {
+			return nil, fmt.Errorf("parsing ssh key: %v", err)
+		}
+		return auth, nil
+	}
+
+	if g.Username != "" || g.Password != "" {
+		return &http.BasicAuth{Username: g.Username, Password: g.Password}, nil
+	}
+
+	return nil, nil
+}
+
+func (g *GoGitDriver) Clone(dir, url string) (string, error) {
+	auth, err := g.auth()
+	if err != nil {
+		return "", err
+	}
+
+	refName := g.referenceName()
+	_, err = git.PlainClone(dir, false, &git.CloneOptions{
+		URL:           url,
+		Auth:          auth,
+		Depth:         1,
+		SingleBranch:  refName != "",
+		ReferenceName: refName,
+	})
+	if err != nil {
+		return "", fmt.Errorf("go-git: clone %s: %v", url, err)
+	}
+
+	return g.HeadRev(dir)
+}
+
+func (g *GoGitDriver) Pull(dir string) (string, error) {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return "", fmt.Errorf("go-git: open %s: %v", dir, err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("go-git: worktree for %s: %v", dir, err)
+	}
+
+	auth, err := g.auth()
+	if err != nil {
+		return "", err
+	}
+
+	err = wt.Pull(&git.PullOptions{
+		RemoteName:    "origin",
+		Auth:          auth,
+		Depth:         1,
+		Force:         true,
+		ReferenceName: g.referenceName(),
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return "", fmt.Errorf("go-git: pull for %s: %v", dir, err)
+	}
+
+	return g.HeadRev(dir)
+}
+
+func (g *GoGitDriver) HeadRev(dir string) (string, error) {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return "", fmt.Errorf("go-git: open %s: %v", dir, err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("go-git: head for %s: %v", dir, err)
+	}
+
+	return head.Hash().String(), nil
+}
+
+func (g *GoGitDriver) SpecialFiles() []string {
+	return []string{".git"}
+}
+
+func (g *GoGitDriver) AutoGeneratedFilePatterns(dir string) []string {
+	return attributeFilePatterns(dir, attrGenerated)
+}
+
+func (g *GoGitDriver) VendoredFilePatterns(dir string) []string {
+	return attributeFilePatterns(dir, attrVendored)
+}
+
+func (g *GoGitDriver) DocumentationFilePatterns(dir string) []string {
+	return attributeFilePatterns(dir, attrDocumentation)
+}
+
+func (g *GoGitDriver) ExcludedFilePatterns(dir string) []string {
+	var patterns []string
+	patterns = append(patterns, g.AutoGeneratedFilePatterns(dir)...)
+	patterns = append(patterns, g.VendoredFilePatterns(dir)...)
+	patterns = append(patterns, g.DocumentationFilePatterns(dir)...)
+	return patterns
+}